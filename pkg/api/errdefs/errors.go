@@ -0,0 +1,58 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package errdefs defines a set of strongly-typed error interfaces for compose
+// service failures, modeled on github.com/docker/docker/errdefs. Producers wrap
+// a cause with the constructors in helpers.go; consumers (CLI, gRPC) use the
+// Is* predicates in is.go instead of matching on error strings.
+package errdefs
+
+// ErrNotFound signals that the requested resource (service, container,
+// project, ...) does not exist.
+type ErrNotFound interface {
+	NotFound()
+}
+
+// ErrConflict signals that the request conflicts with the current state of
+// the resource it targets.
+type ErrConflict interface {
+	Conflict()
+}
+
+// ErrInvalidParameter signals that an argument or compose-file field was
+// malformed or out of range.
+type ErrInvalidParameter interface {
+	InvalidParameter()
+}
+
+// ErrUnhealthyDependency signals that a dependency cannot be confirmed ready:
+// a depends_on: condition: service_healthy dependency has no healthcheck
+// configured, or an x-depends-on-probe readiness probe used up its retries.
+type ErrUnhealthyDependency interface {
+	UnhealthyDependency()
+}
+
+// ErrDependencyExitedNonZero signals that a depends_on: condition:
+// service_completed_successfully dependency exited with a non-zero code.
+type ErrDependencyExitedNonZero interface {
+	DependencyExitedNonZero()
+}
+
+// ErrScaleConflict signals that a requested scale is incompatible with the
+// service configuration, e.g. scale > 1 combined with a fixed container_name.
+type ErrScaleConflict interface {
+	ScaleConflict()
+}
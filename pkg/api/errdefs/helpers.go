@@ -0,0 +1,85 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package errdefs
+
+// causer exposes the wrapped error so errors.Is/As and %w keep working.
+type causer struct {
+	cause error
+}
+
+func (e causer) Cause() error  { return e.cause }
+func (e causer) Unwrap() error { return e.cause }
+func (e causer) Error() string { return e.cause.Error() }
+
+type errNotFound struct{ causer }
+
+func (errNotFound) NotFound() {}
+
+// NotFound wraps cause as an ErrNotFound.
+func NotFound(cause error) error {
+	return errNotFound{causer{cause}}
+}
+
+type errConflict struct{ causer }
+
+func (errConflict) Conflict() {}
+
+// Conflict wraps cause as an ErrConflict.
+func Conflict(cause error) error {
+	return errConflict{causer{cause}}
+}
+
+type errInvalidParameter struct{ causer }
+
+func (errInvalidParameter) InvalidParameter() {}
+
+// InvalidParameter wraps cause as an ErrInvalidParameter.
+func InvalidParameter(cause error) error {
+	return errInvalidParameter{causer{cause}}
+}
+
+type errUnhealthyDependency struct{ causer }
+
+func (errUnhealthyDependency) UnhealthyDependency() {}
+
+// UnhealthyDependency wraps cause as an ErrUnhealthyDependency.
+func UnhealthyDependency(cause error) error {
+	return errUnhealthyDependency{causer{cause}}
+}
+
+type errDependencyExitedNonZero struct {
+	causer
+	Service  string
+	ExitCode int
+}
+
+func (errDependencyExitedNonZero) DependencyExitedNonZero() {}
+
+// DependencyExitedNonZero wraps cause as an ErrDependencyExitedNonZero, recording which
+// service exited and with what code so callers can report it without re-parsing the message.
+func DependencyExitedNonZero(service string, exitCode int, cause error) error {
+	return errDependencyExitedNonZero{causer{cause}, service, exitCode}
+}
+
+type errScaleConflict struct{ causer }
+
+func (errScaleConflict) ScaleConflict() {}
+
+// ScaleConflict wraps cause as an ErrScaleConflict.
+func ScaleConflict(cause error) error {
+	return errScaleConflict{causer{cause}}
+}
@@ -0,0 +1,96 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package errdefs
+
+import "errors"
+
+// IsNotFound returns true if err, or any error it wraps, is an ErrNotFound.
+func IsNotFound(err error) bool {
+	var e ErrNotFound
+	return errors.As(err, &e)
+}
+
+// IsConflict returns true if err, or any error it wraps, is an ErrConflict.
+func IsConflict(err error) bool {
+	var e ErrConflict
+	return errors.As(err, &e)
+}
+
+// IsInvalidParameter returns true if err, or any error it wraps, is an ErrInvalidParameter.
+func IsInvalidParameter(err error) bool {
+	var e ErrInvalidParameter
+	return errors.As(err, &e)
+}
+
+// IsUnhealthyDependency returns true if err, or any error it wraps, is an ErrUnhealthyDependency.
+func IsUnhealthyDependency(err error) bool {
+	var e ErrUnhealthyDependency
+	return errors.As(err, &e)
+}
+
+// IsDependencyExitedNonZero returns true if err, or any error it wraps, is an
+// ErrDependencyExitedNonZero.
+func IsDependencyExitedNonZero(err error) bool {
+	var e ErrDependencyExitedNonZero
+	return errors.As(err, &e)
+}
+
+// IsScaleConflict returns true if err, or any error it wraps, is an ErrScaleConflict.
+func IsScaleConflict(err error) bool {
+	var e ErrScaleConflict
+	return errors.As(err, &e)
+}
+
+// Code is a coarse classification of an error, independent of its cause, that
+// callers (CLI exit codes, gRPC status codes) can switch on without string
+// matching.
+type Code int
+
+const (
+	// CodeUnknown is returned for errors that don't implement any of the
+	// typed interfaces in this package.
+	CodeUnknown Code = iota
+	CodeNotFound
+	CodeConflict
+	CodeInvalidParameter
+	CodeUnhealthyDependency
+	CodeDependencyExitedNonZero
+	CodeScaleConflict
+)
+
+// Classify maps err to a Code by walking its cause chain against the typed
+// error interfaces declared in this package.
+func Classify(err error) Code {
+	switch {
+	case err == nil:
+		return CodeUnknown
+	case IsNotFound(err):
+		return CodeNotFound
+	case IsConflict(err):
+		return CodeConflict
+	case IsInvalidParameter(err):
+		return CodeInvalidParameter
+	case IsUnhealthyDependency(err):
+		return CodeUnhealthyDependency
+	case IsDependencyExitedNonZero(err):
+		return CodeDependencyExitedNonZero
+	case IsScaleConflict(err):
+		return CodeScaleConflict
+	default:
+		return CodeUnknown
+	}
+}
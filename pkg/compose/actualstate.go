@@ -0,0 +1,104 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+
+	"github.com/docker/compose-cli/pkg/api"
+)
+
+// ActualState reconstructs a *types.Project, and the Containers backing it, purely from labels
+// on the containers currently on the engine for projectName -- no compose file required. It is
+// the basis for drift detection in DriftingServices, its only caller in this tree; no
+// `compose down`/`compose ps` command uses it. One-off (`compose
+// run`) containers are excluded, the same as every other container listing in this package,
+// so they aren't mistaken for scaled service replicas. When services is non-empty, only those
+// service names are included.
+func (s *composeService) ActualState(ctx context.Context, projectName string, services []string) (Containers, *types.Project, error) {
+	list, err := s.apiClient.ContainerList(ctx, moby.ContainerListOptions{
+		Filters: filters.NewArgs(projectFilter(projectName), oneOffFilter(false)),
+		All:     true,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	containers := Containers(list)
+	if len(services) > 0 {
+		wanted := map[string]bool{}
+		for _, name := range services {
+			wanted[name] = true
+		}
+		containers = containers.filter(func(c moby.Container) bool {
+			return wanted[c.Labels[api.ServiceLabel]]
+		})
+	}
+
+	byService := map[string][]moby.Container{}
+	for _, c := range containers {
+		name := c.Labels[api.ServiceLabel]
+		byService[name] = append(byService[name], c)
+	}
+
+	project := &types.Project{Name: projectName}
+	for name, instances := range byService {
+		project.Services = append(project.Services, types.ServiceConfig{
+			Name:   name,
+			Image:  instances[0].Image,
+			Labels: instances[0].Labels,
+			Scale:  len(instances),
+		})
+	}
+	return containers, project, nil
+}
+
+// DriftingServices reports the names of project's services whose containers (running or stopped)
+// no longer carry the ConfigHashLabel that ServiceHash computes from the compose file, i.e. the
+// service was changed (or the containers were created from a since-edited definition) since it was
+// last converged. No command in this tree calls it.
+func (s *composeService) DriftingServices(ctx context.Context, project *types.Project) ([]string, error) {
+	containers, _, err := s.ActualState(ctx, project.Name, project.ServiceNames())
+	if err != nil {
+		return nil, err
+	}
+
+	byService := map[string][]moby.Container{}
+	for _, c := range containers {
+		name := c.Labels[api.ServiceLabel]
+		byService[name] = append(byService[name], c)
+	}
+
+	var drifting []string
+	for _, service := range project.Services {
+		expected, err := ServiceHash(service)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range byService[service.Name] {
+			if c.Labels[api.ConfigHashLabel] != expected {
+				drifting = append(drifting, service.Name)
+				break
+			}
+		}
+	}
+	return drifting, nil
+}
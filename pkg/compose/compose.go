@@ -0,0 +1,41 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"github.com/docker/docker/client"
+
+	"github.com/docker/compose-cli/pkg/api"
+)
+
+type composeService struct {
+	apiClient client.APIClient
+	limiter   *concurrencyLimiter
+}
+
+// NewComposeService creates a compose.Service backed by apiClient. The concurrency limit
+// defaults to COMPOSE_PARALLEL_LIMIT (unbounded if unset); pass WithConcurrencyLimit to override.
+func NewComposeService(apiClient client.APIClient, opts ...ServiceOption) api.Service {
+	s := &composeService{
+		apiClient: apiClient,
+		limiter:   newConcurrencyLimiter(defaultConcurrencyLimit()),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
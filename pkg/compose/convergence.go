@@ -33,6 +33,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/docker/compose-cli/pkg/api"
+	"github.com/docker/compose-cli/pkg/api/errdefs"
 	"github.com/docker/compose-cli/pkg/progress"
 	"github.com/docker/compose-cli/pkg/utils"
 )
@@ -67,7 +68,7 @@ func (s *composeService) ensureScale(ctx context.Context, project *types.Project
 		for i := 0; i < missing; i++ {
 			number := next + i
 			name := getContainerName(project.Name, service, number)
-			eg.Go(func() error {
+			s.runBounded(eg, func() error {
 				return s.createContainer(ctx, project, service, name, number, false, true)
 			})
 		}
@@ -76,7 +77,7 @@ func (s *composeService) ensureScale(ctx context.Context, project *types.Project
 	if len(actual) > scale {
 		for i := scale; i < len(actual); i++ {
 			container := actual[i]
-			eg.Go(func() error {
+			s.runBounded(eg, func() error {
 				err := s.apiClient.ContainerStop(ctx, container.ID, timeout)
 				if err != nil {
 					return err
@@ -104,15 +105,14 @@ func (s *composeService) ensureService(ctx context.Context, project *types.Proje
 		return err
 	}
 
+	var toRecreate []moby.Container
 	for _, container := range actual {
 		container := container
 		name := getContainerProgressName(container)
 
 		diverged := container.Labels[api.ConfigHashLabel] != expected
 		if diverged || recreate == api.RecreateForce || service.Extensions[extLifecycle] == forceRecreate {
-			eg.Go(func() error {
-				return s.recreateContainer(ctx, project, service, container, inherit, timeout)
-			})
+			toRecreate = append(toRecreate, container)
 			continue
 		}
 
@@ -125,12 +125,20 @@ func (s *composeService) ensureService(ctx context.Context, project *types.Proje
 		case ContainerExited:
 			w.Event(progress.CreatedEvent(name))
 		default:
-			eg.Go(func() error {
+			s.runBounded(eg, func() error {
 				return s.startContainer(ctx, container)
 			})
 		}
 	}
-	return eg.Wait()
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	if len(toRecreate) == 0 {
+		return nil
+	}
+	// rolling update: batch the recreates per deploy.update_config instead of firing them
+	// all at once, so parallelism/delay/failure_action are honored.
+	return s.rollingUpdate(ctx, project, service, toRecreate, inherit, timeout)
 }
 
 func getContainerName(projectName string, service types.ServiceConfig, number int) string {
@@ -146,10 +154,18 @@ func getContainerProgressName(container moby.Container) string {
 }
 
 func (s *composeService) waitDependencies(ctx context.Context, project *types.Project, service types.ServiceConfig) error {
+	probes, err := dependencyProbes(service)
+	if err != nil {
+		return err
+	}
+
 	eg, _ := errgroup.WithContext(ctx)
 	for dep, config := range service.DependsOn {
 		dep, config := dep, config
 		eg.Go(func() error {
+			if probe, ok := probes[dep]; ok {
+				return s.waitProbe(ctx, project, dep, probe)
+			}
 			ticker := time.NewTicker(500 * time.Millisecond)
 			defer ticker.Stop()
 			for {
@@ -170,7 +186,8 @@ func (s *composeService) waitDependencies(ctx context.Context, project *types.Pr
 					}
 					if exited {
 						if code != 0 {
-							return fmt.Errorf("service %q didn't completed successfully: exit %d", dep, code)
+							return errdefs.DependencyExitedNonZero(dep, code,
+								fmt.Errorf("service %q didn't completed successfully: exit %d", dep, code))
 						}
 						return nil
 					}
@@ -192,7 +209,7 @@ func nextContainerNumber(containers []moby.Container) (int, error) {
 	for _, c := range containers {
 		n, err := strconv.Atoi(c.Labels[api.ContainerNumberLabel])
 		if err != nil {
-			return 0, err
+			return 0, errdefs.InvalidParameter(fmt.Errorf("container %q has an invalid %s label: %w", c.ID, api.ContainerNumberLabel, err))
 		}
 		if n > max {
 			max = n
@@ -213,9 +230,9 @@ func getScale(config types.ServiceConfig) (int, error) {
 	}
 	if scale > 1 && config.ContainerName != "" {
 		scale = -1
-		err = fmt.Errorf(doubledContainerNameWarning,
+		err = errdefs.ScaleConflict(fmt.Errorf(doubledContainerNameWarning,
 			config.Name,
-			config.ContainerName)
+			config.ContainerName))
 	}
 	return scale, err
 }
@@ -224,7 +241,7 @@ func (s *composeService) createContainer(ctx context.Context, project *types.Pro
 	w := progress.ContextWriter(ctx)
 	eventName := "Container " + name
 	w.Event(progress.CreatingEvent(eventName))
-	err := s.createMobyContainer(ctx, project, service, name, number, nil, autoRemove, useNetworkAliases)
+	_, err := s.createMobyContainer(ctx, project, service, name, number, nil, autoRemove, useNetworkAliases, "")
 	if err != nil {
 		return err
 	}
@@ -232,6 +249,7 @@ func (s *composeService) createContainer(ctx context.Context, project *types.Pro
 	return nil
 }
 
+// recreateContainer implements the default order: stop || rename || create || remove.
 func (s *composeService) recreateContainer(ctx context.Context, project *types.Project, service types.ServiceConfig, container moby.Container, inherit bool, timeout *time.Duration) error {
 	w := progress.ContextWriter(ctx)
 	w.Event(progress.NewEvent(getContainerProgressName(container), progress.Working, "Recreate"))
@@ -254,7 +272,7 @@ func (s *composeService) recreateContainer(ctx context.Context, project *types.P
 	if inherit {
 		inherited = &container
 	}
-	err = s.createMobyContainer(ctx, project, service, name, number, inherited, false, true)
+	_, err = s.createMobyContainer(ctx, project, service, name, number, inherited, false, true, container.Labels[api.ConfigHashLabel])
 	if err != nil {
 		return err
 	}
@@ -291,33 +309,49 @@ func (s *composeService) startContainer(ctx context.Context, container moby.Cont
 	return nil
 }
 
+// createMobyContainer creates the container for service. When previousConfigHash is non-empty
+// (set by a rolling update recreating a container), it is stashed on the new container
+// under previousConfigHashLabel.
 func (s *composeService) createMobyContainer(ctx context.Context, project *types.Project, service types.ServiceConfig, name string, number int,
 	inherit *moby.Container,
 	autoRemove bool,
-	useNetworkAliases bool) error {
+	useNetworkAliases bool,
+	previousConfigHash string) (moby.Container, error) {
 	cState, err := GetContextContainerState(ctx)
 	if err != nil {
-		return err
+		return moby.Container{}, err
 	}
 	containerConfig, hostConfig, networkingConfig, err := s.getCreateOptions(ctx, project, service, number, inherit, autoRemove)
 	if err != nil {
-		return err
+		return moby.Container{}, err
+	}
+	if previousConfigHash != "" {
+		if containerConfig.Labels == nil {
+			containerConfig.Labels = map[string]string{}
+		}
+		containerConfig.Labels[previousConfigHashLabel] = previousConfigHash
 	}
 	var plat *specs.Platform
 	if service.Platform != "" {
 		p, err := platforms.Parse(service.Platform)
 		if err != nil {
-			return err
+			return moby.Container{}, err
 		}
 		plat = &p
 	}
 	created, err := s.apiClient.ContainerCreate(ctx, containerConfig, hostConfig, networkingConfig, plat, name)
+	for attempt := 0; err != nil && isTransientDaemonError(err) && attempt < containerCreateRetries; attempt++ {
+		if werr := waitContext(ctx, containerCreateBackoff(attempt)); werr != nil {
+			return moby.Container{}, werr
+		}
+		created, err = s.apiClient.ContainerCreate(ctx, containerConfig, hostConfig, networkingConfig, plat, name)
+	}
 	if err != nil {
-		return err
+		return moby.Container{}, err
 	}
 	inspectedContainer, err := s.apiClient.ContainerInspect(ctx, created.ID)
 	if err != nil {
-		return err
+		return moby.Container{}, err
 	}
 	createdContainer := moby.Container{
 		ID:     inspectedContainer.ID,
@@ -330,7 +364,7 @@ func (s *composeService) createMobyContainer(ctx context.Context, project *types
 	cState.Add(createdContainer)
 	links, err := s.getLinks(ctx, service)
 	if err != nil {
-		return err
+		return moby.Container{}, err
 	}
 	for _, netName := range service.NetworksByPriority() {
 		netwrk := project.Networks[netName]
@@ -348,15 +382,15 @@ func (s *composeService) createMobyContainer(ctx context.Context, project *types
 			}
 			err := s.apiClient.NetworkDisconnect(ctx, netwrk.Name, createdContainer.ID, false)
 			if err != nil {
-				return err
+				return moby.Container{}, err
 			}
 		}
 		err = s.connectContainerToNetwork(ctx, created.ID, netwrk.Name, cfg, links, aliases...)
 		if err != nil {
-			return err
+			return moby.Container{}, err
 		}
 	}
-	return nil
+	return createdContainer, nil
 }
 
 func shortIDAliasExists(containerID string, aliases ...string) bool {
@@ -435,7 +469,7 @@ func (s *composeService) isServiceHealthy(ctx context.Context, project *types.Pr
 			return false, err
 		}
 		if container.State == nil || container.State.Health == nil {
-			return false, fmt.Errorf("container for service %q has no healthcheck configured", service)
+			return false, errdefs.UnhealthyDependency(fmt.Errorf("container for service %q has no healthcheck configured", service))
 		}
 		if container.State.Health.Status != moby.Healthy {
 			return false, nil
@@ -485,7 +519,7 @@ func (s *composeService) startService(ctx context.Context, project *types.Projec
 		if container.State == ContainerRunning {
 			continue
 		}
-		eg.Go(func() error {
+		s.runBounded(eg, func() error {
 			eventName := getContainerProgressName(container)
 			w.Event(progress.StartingEvent(eventName))
 			err := s.apiClient.ContainerStart(ctx, container.ID, moby.ContainerStartOptions{})
@@ -508,7 +542,7 @@ func (s *composeService) restartService(ctx context.Context, serviceName string,
 	eg, ctx := errgroup.WithContext(ctx)
 	for _, c := range containers {
 		container := c
-		eg.Go(func() error {
+		s.runBounded(eg, func() error {
 			eventName := getContainerProgressName(container)
 			w.Event(progress.RestartingEvent(eventName))
 			err := s.apiClient.ContainerRestart(ctx, container.ID, timeout)
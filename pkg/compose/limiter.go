@@ -0,0 +1,100 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	dockererrdefs "github.com/docker/docker/errdefs"
+)
+
+// composeParallelLimitEnv matches classic docker-compose's COMPOSE_PARALLEL_LIMIT: it caps how
+// many container operations (create/start/stop/remove) run at once against the daemon.
+const composeParallelLimitEnv = "COMPOSE_PARALLEL_LIMIT"
+
+// ServiceOption configures a composeService created by NewComposeService.
+type ServiceOption func(*composeService)
+
+// WithConcurrencyLimit caps the number of container create/start/stop/remove operations a
+// composeService runs concurrently. A limit <= 0 means unbounded.
+func WithConcurrencyLimit(limit int) ServiceOption {
+	return func(s *composeService) {
+		s.limiter = newConcurrencyLimiter(limit)
+	}
+}
+
+// concurrencyLimiter is a semaphore-gated worker pool; a nil *concurrencyLimiter is unbounded.
+type concurrencyLimiter struct {
+	sem chan struct{}
+}
+
+func newConcurrencyLimiter(limit int) *concurrencyLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &concurrencyLimiter{sem: make(chan struct{}, limit)}
+}
+
+func defaultConcurrencyLimit() int {
+	v, ok := os.LookupEnv(composeParallelLimitEnv)
+	if !ok {
+		return 0
+	}
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit <= 0 {
+		return 0
+	}
+	return limit
+}
+
+// containerCreateRetries bounds the retry-with-backoff wrapper around ContainerCreate used for
+// transient daemon errors (429 rate-limiting, temporary unavailability).
+const containerCreateRetries = 3
+
+func containerCreateBackoff(attempt int) time.Duration {
+	return time.Duration(1<<uint(attempt)) * 200 * time.Millisecond
+}
+
+// isTransientDaemonError reports whether err is worth retrying: the daemon rate-limited us or is
+// momentarily unavailable, as opposed to a permanent failure like an invalid image reference.
+func isTransientDaemonError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if dockererrdefs.IsUnavailable(err) || dockererrdefs.IsSystem(err) {
+		return true
+	}
+	return strings.Contains(err.Error(), "429") || strings.Contains(err.Error(), "Too Many Requests")
+}
+
+// runBounded schedules fn on eg, gated by s.limiter when one is configured, so callers can swap
+// a plain eg.Go(fn) for s.runBounded(eg, fn) to respect the limit set by WithConcurrencyLimit or
+// COMPOSE_PARALLEL_LIMIT.
+func (s *composeService) runBounded(eg *errgroup.Group, fn func() error) {
+	eg.Go(func() error {
+		if s.limiter != nil {
+			s.limiter.sem <- struct{}{}
+			defer func() { <-s.limiter.sem }()
+		}
+		return fn()
+	})
+}
@@ -0,0 +1,148 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+
+	"github.com/docker/compose-cli/pkg/api/errdefs"
+)
+
+// extDependsOnProbe is a service-level extension that attaches a user-defined readiness probe
+// to one or more depends_on entries, for peers that don't expose a Docker HEALTHCHECK:
+//
+//	depends_on:
+//	  backend:
+//	    condition: service_started
+//	x-depends-on-probe:
+//	  backend:
+//	    type: http
+//	    url: http://backend:8080/ready
+//	    interval: 2s
+//	    timeout: 1s
+//	    retries: 5
+const extDependsOnProbe = "x-depends-on-probe"
+
+// ProbeConfig is a single entry of x-depends-on-probe, keyed by the dependency service name.
+// Interval/Timeout use compose-go's types.Duration (not time.Duration) so compose-file values
+// like "2s" decode the same way deploy.update_config.delay does, instead of requiring a raw
+// nanosecond count.
+type ProbeConfig struct {
+	Type     string         `json:"type"` // tcp | http | https | exec | grpc
+	Address  string         `json:"address,omitempty"`
+	URL      string         `json:"url,omitempty"`
+	Status   int            `json:"status,omitempty"`
+	Command  []string       `json:"command,omitempty"`
+	Interval types.Duration `json:"interval,omitempty"`
+	Timeout  types.Duration `json:"timeout,omitempty"`
+	Retries  int            `json:"retries,omitempty"`
+}
+
+// Probe checks whether a dependency container has reached a ready state.
+type Probe interface {
+	Check(ctx context.Context, container moby.Container) (bool, error)
+}
+
+// dependencyProbes decodes the x-depends-on-probe extension of service, applying defaults of
+// interval 1s, timeout 1s and retries 3 where they are unset.
+func dependencyProbes(service types.ServiceConfig) (map[string]ProbeConfig, error) {
+	raw, ok := service.Extensions[extDependsOnProbe]
+	if !ok {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, errdefs.InvalidParameter(fmt.Errorf("service %q has an invalid %s extension: %w", service.Name, extDependsOnProbe, err))
+	}
+	probes := map[string]ProbeConfig{}
+	if err := json.Unmarshal(data, &probes); err != nil {
+		return nil, errdefs.InvalidParameter(fmt.Errorf("service %q has an invalid %s extension: %w", service.Name, extDependsOnProbe, err))
+	}
+	for dep, cfg := range probes {
+		if cfg.Interval == 0 {
+			cfg.Interval = types.Duration(time.Second)
+		}
+		if cfg.Timeout == 0 {
+			cfg.Timeout = types.Duration(time.Second)
+		}
+		if cfg.Retries == 0 {
+			cfg.Retries = 3
+		}
+		probes[dep] = cfg
+	}
+	return probes, nil
+}
+
+// newProbe builds the Probe implementation matching config.Type.
+func (s *composeService) newProbe(config ProbeConfig) (Probe, error) {
+	switch config.Type {
+	case "tcp":
+		return &tcpProbe{address: config.Address}, nil
+	case "http", "https":
+		return &httpProbe{url: config.URL, status: config.Status}, nil
+	case "exec":
+		return &execProbe{service: s, command: config.Command}, nil
+	case "grpc":
+		return &grpcHealthProbe{address: config.Address}, nil
+	default:
+		return nil, errdefs.InvalidParameter(fmt.Errorf("unsupported %s type %q", extDependsOnProbe, config.Type))
+	}
+}
+
+// waitProbe polls dep's containers with the configured probe until all of them report ready,
+// or the probe's retry budget is exhausted.
+func (s *composeService) waitProbe(ctx context.Context, project *types.Project, dep string, config ProbeConfig) error {
+	probe, err := s.newProbe(config)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(time.Duration(config.Interval))
+	defer ticker.Stop()
+	attempts := 0
+	for {
+		<-ticker.C
+		containers, err := s.getContainers(ctx, project.Name, oneOffExclude, false, dep)
+		if err != nil {
+			return err
+		}
+		ready := len(containers) > 0
+		for _, container := range containers {
+			probeCtx, cancel := context.WithTimeout(ctx, time.Duration(config.Timeout))
+			ok, err := probe.Check(probeCtx, container)
+			cancel()
+			if err != nil || !ok {
+				ready = false
+				break
+			}
+		}
+		if ready {
+			return nil
+		}
+		attempts++
+		if attempts >= config.Retries {
+			return errdefs.UnhealthyDependency(
+				fmt.Errorf("dependency %q did not become ready via %s probe after %d attempts", dep, config.Type, attempts))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+
+	moby "github.com/docker/docker/api/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// tcpProbe reports ready as soon as a TCP connection to address succeeds.
+type tcpProbe struct {
+	address string
+}
+
+func (p *tcpProbe) Check(ctx context.Context, _ moby.Container) (bool, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return false, nil
+	}
+	_ = conn.Close()
+	return true, nil
+}
+
+// httpProbe reports ready when a GET of url returns status (defaults to 200).
+type httpProbe struct {
+	url    string
+	status int
+}
+
+func (p *httpProbe) Check(ctx context.Context, _ moby.Container) (bool, error) {
+	want := p.status
+	if want == 0 {
+		want = http.StatusOK
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, nil
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == want, nil
+}
+
+// execProbe reports ready when command exits zero inside the dependency container.
+type execProbe struct {
+	service *composeService
+	command []string
+}
+
+func (p *execProbe) Check(ctx context.Context, container moby.Container) (bool, error) {
+	exec, err := p.service.apiClient.ContainerExecCreate(ctx, container.ID, moby.ExecConfig{
+		Cmd:          p.command,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return false, err
+	}
+	resp, err := p.service.apiClient.ContainerExecAttach(ctx, exec.ID, moby.ExecStartCheck{})
+	if err != nil {
+		return false, err
+	}
+	defer resp.Close()
+	_, _ = bytes.NewBuffer(nil).ReadFrom(resp.Reader)
+	inspect, err := p.service.apiClient.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return false, err
+	}
+	if inspect.Running {
+		return false, nil
+	}
+	return inspect.ExitCode == 0, nil
+}
+
+// grpcHealthProbe speaks the grpc.health.v1 protocol against address, reporting ready only
+// when the server returns SERVING for the default (empty) service.
+type grpcHealthProbe struct {
+	address string
+}
+
+func (p *grpcHealthProbe) Check(ctx context.Context, _ moby.Container) (bool, error) {
+	conn, err := grpc.DialContext(ctx, p.address, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	if err != nil {
+		return false, nil
+	}
+	defer conn.Close()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return false, nil
+	}
+	return resp.Status == healthpb.HealthCheckResponse_SERVING, nil
+}
@@ -0,0 +1,98 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencyProbesDecodesDurationStrings(t *testing.T) {
+	service := types.ServiceConfig{
+		Name: "web",
+		Extensions: map[string]interface{}{
+			extDependsOnProbe: map[string]interface{}{
+				"backend": map[string]interface{}{
+					"type":     "http",
+					"url":      "http://backend:8080/ready",
+					"interval": "2s",
+					"timeout":  "1s",
+					"retries":  5,
+				},
+			},
+		},
+	}
+
+	probes, err := dependencyProbes(service)
+	require.NoError(t, err)
+	require.Contains(t, probes, "backend")
+
+	backend := probes["backend"]
+	assert.Equal(t, "http", backend.Type)
+	assert.Equal(t, "http://backend:8080/ready", backend.URL)
+	assert.Equal(t, types.Duration(2*time.Second), backend.Interval)
+	assert.Equal(t, types.Duration(time.Second), backend.Timeout)
+	assert.Equal(t, 5, backend.Retries)
+}
+
+func TestDependencyProbesAppliesDefaults(t *testing.T) {
+	service := types.ServiceConfig{
+		Name: "web",
+		Extensions: map[string]interface{}{
+			extDependsOnProbe: map[string]interface{}{
+				"backend": map[string]interface{}{
+					"type":    "tcp",
+					"address": "backend:5432",
+				},
+			},
+		},
+	}
+
+	probes, err := dependencyProbes(service)
+	require.NoError(t, err)
+
+	backend := probes["backend"]
+	assert.Equal(t, types.Duration(time.Second), backend.Interval)
+	assert.Equal(t, types.Duration(time.Second), backend.Timeout)
+	assert.Equal(t, 3, backend.Retries)
+}
+
+func TestDependencyProbesNoExtension(t *testing.T) {
+	probes, err := dependencyProbes(types.ServiceConfig{Name: "web"})
+	require.NoError(t, err)
+	assert.Nil(t, probes)
+}
+
+func TestDependencyProbesInvalidExtension(t *testing.T) {
+	service := types.ServiceConfig{
+		Name: "web",
+		Extensions: map[string]interface{}{
+			extDependsOnProbe: map[string]interface{}{
+				"backend": map[string]interface{}{
+					"interval": "not-a-duration",
+				},
+			},
+		},
+	}
+
+	_, err := dependencyProbes(service)
+	assert.Error(t, err)
+}
@@ -0,0 +1,367 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/docker/compose-cli/pkg/api"
+	"github.com/docker/compose-cli/pkg/progress"
+)
+
+// previousConfigHashLabel stashes the config hash a container carried before it was recreated
+// by a rolling update. rollback does not read it: it restores from the pre-recreate snapshots
+// matched by container number.
+const previousConfigHashLabel = "com.docker.compose.config-hash.previous"
+
+const (
+	updateOrderStopFirst  = "stop-first"
+	updateOrderStartFirst = "start-first"
+
+	updateFailureActionContinue = "continue"
+	updateFailureActionRollback = "rollback"
+	updateFailureActionPause    = "pause"
+)
+
+// monitorPollInterval is how often monitorBatch re-checks container state while waiting out
+// deploy.update_config.monitor.
+const monitorPollInterval = 500 * time.Millisecond
+
+// rollingUpdatePlan is deploy.update_config resolved to concrete values, defaults applied.
+type rollingUpdatePlan struct {
+	parallelism     int
+	delay           time.Duration
+	order           string
+	failureAction   string
+	monitor         time.Duration
+	maxFailureRatio float32
+}
+
+func newRollingUpdatePlan(service types.ServiceConfig, total int) rollingUpdatePlan {
+	plan := rollingUpdatePlan{
+		parallelism:   total,
+		order:         updateOrderStopFirst,
+		failureAction: updateFailureActionContinue,
+	}
+	if service.Deploy == nil || service.Deploy.UpdateConfig == nil {
+		return plan
+	}
+	update := service.Deploy.UpdateConfig
+	if update.Parallelism != nil && *update.Parallelism > 0 {
+		plan.parallelism = int(*update.Parallelism)
+	}
+	plan.delay = time.Duration(update.Delay)
+	plan.monitor = time.Duration(update.Monitor)
+	plan.maxFailureRatio = float32(update.MaxFailureRatio)
+	if update.Order != "" {
+		plan.order = update.Order
+	}
+	if update.FailureAction != "" {
+		plan.failureAction = update.FailureAction
+	}
+	return plan
+}
+
+// shouldContinueOnFailureRatio reports whether totalFailures out of total containers is still
+// within plan.maxFailureRatio, i.e. the rollout can proceed to the next batch without consulting
+// plan.failureAction at all.
+func shouldContinueOnFailureRatio(plan rollingUpdatePlan, totalFailures, total int) bool {
+	if plan.maxFailureRatio <= 0 || total == 0 {
+		return false
+	}
+	ratio := float32(totalFailures) / float32(total)
+	return ratio <= plan.maxFailureRatio
+}
+
+// rollingUpdate recreates containers in batches of plan.parallelism. Between every batch --
+// whether or not that batch had failures -- it waits plan.monitor (polling the service's
+// containers for new failures surfacing after the recreate calls returned) and then plan.delay,
+// before moving to the next batch. Failures are tracked per container (not inferred from the
+// batch's aggregate error) so max_failure_ratio reflects how many containers actually failed, and
+// priors (pre-recreate ContainerJSON snapshots, keyed by the stable container number label) are
+// captured before every recreate attempt so a mid-recreate failure is itself recoverable by
+// rollback.
+func (s *composeService) rollingUpdate(ctx context.Context, project *types.Project, service types.ServiceConfig, containers []moby.Container, inherit bool, timeout *time.Duration) error {
+	plan := newRollingUpdatePlan(service, len(containers))
+	w := progress.ContextWriter(ctx)
+
+	priors := map[string]moby.ContainerJSON{}
+	var priorsMu sync.Mutex
+	var firstErr error
+	var totalFailures int
+
+	for start := 0; start < len(containers); start += plan.parallelism {
+		end := start + plan.parallelism
+		if end > len(containers) {
+			end = len(containers)
+		}
+		batch := containers[start:end]
+		errs := make([]error, len(batch))
+
+		eg, batchCtx := errgroup.WithContext(ctx)
+		for i, container := range batch {
+			i, container := i, container
+			s.runBounded(eg, func() error {
+				if inspected, ierr := s.apiClient.ContainerInspect(batchCtx, container.ID); ierr == nil {
+					priorsMu.Lock()
+					priors[container.Labels[api.ContainerNumberLabel]] = inspected
+					priorsMu.Unlock()
+				}
+				errs[i] = s.recreateContainerOrdered(batchCtx, project, service, container, inherit, timeout, plan.order)
+				return nil
+			})
+		}
+		_ = eg.Wait()
+
+		batchFailures := 0
+		for i, err := range errs {
+			if err == nil {
+				continue
+			}
+			batchFailures++
+			totalFailures++
+			if firstErr == nil {
+				firstErr = err
+			}
+			w.Event(progress.NewEvent(getContainerProgressName(batch[i]), progress.Error, "Update failed: "+err.Error()))
+		}
+
+		if batchFailures > 0 {
+			abort, err := s.reactToUpdateFailures(ctx, project, service, plan, priors, timeout, batchFailures, totalFailures, len(containers), firstErr)
+			if abort {
+				return err
+			}
+		}
+
+		if end >= len(containers) {
+			break
+		}
+
+		monitorFailed, err := s.monitorBatch(ctx, project, service, plan.monitor)
+		if err != nil {
+			return err
+		}
+		if len(monitorFailed) > 0 {
+			totalFailures += len(monitorFailed)
+			for _, c := range monitorFailed {
+				w.Event(progress.NewEvent(getContainerProgressName(c), progress.Error, "Container exited during monitor window"))
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("service %q: container %s exited during monitor window", service.Name, getCanonicalContainerName(monitorFailed[0]))
+			}
+			abort, err := s.reactToUpdateFailures(ctx, project, service, plan, priors, timeout, len(monitorFailed), totalFailures, len(containers), firstErr)
+			if abort {
+				return err
+			}
+		}
+
+		if waitErr := waitContext(ctx, plan.delay); waitErr != nil {
+			return waitErr
+		}
+	}
+	return nil
+}
+
+// reactToUpdateFailures applies plan.maxFailureRatio and plan.failureAction to a set of failures
+// observed either from a batch's recreate calls or from monitorBatch's post-batch polling. It
+// reports abort=true (with the error to return) when the rollout should stop, or abort=false when
+// the caller should proceed to the next batch.
+func (s *composeService) reactToUpdateFailures(ctx context.Context, project *types.Project, service types.ServiceConfig, plan rollingUpdatePlan, priors map[string]moby.ContainerJSON, timeout *time.Duration, newFailures, totalFailures, total int, firstErr error) (bool, error) {
+	w := progress.ContextWriter(ctx)
+
+	if shouldContinueOnFailureRatio(plan, totalFailures, total) {
+		w.Event(progress.NewEvent(service.Name, progress.Warning,
+			fmt.Sprintf("%d/%d containers failed to update, within max_failure_ratio, continuing", totalFailures, total)))
+		return false, nil
+	}
+
+	switch plan.failureAction {
+	case updateFailureActionContinue:
+		w.Event(progress.NewEvent(service.Name, progress.Warning,
+			fmt.Sprintf("%d containers failed to update, continuing", newFailures)))
+		return false, nil
+	case updateFailureActionRollback:
+		w.Event(progress.NewEvent(service.Name, progress.Warning, "Rolling back update"))
+		return true, s.rollback(ctx, project, service, priors, timeout)
+	case updateFailureActionPause:
+		return true, fmt.Errorf("update for service %q paused after failure: %w", service.Name, firstErr)
+	default:
+		return true, firstErr
+	}
+}
+
+// monitorBatch polls the service's containers every monitorPollInterval for the duration of
+// monitor, returning any container observed to have exited during that window. This is what lets
+// deploy.update_config.monitor actually observe a container that crashes only after its recreate
+// call returned successfully, instead of just sleeping through the window.
+func (s *composeService) monitorBatch(ctx context.Context, project *types.Project, service types.ServiceConfig, monitor time.Duration) ([]moby.Container, error) {
+	if monitor <= 0 {
+		return nil, nil
+	}
+	deadline := time.Now().Add(monitor)
+	ticker := time.NewTicker(monitorPollInterval)
+	defer ticker.Stop()
+
+	seen := map[string]bool{}
+	var exited []moby.Container
+	for {
+		select {
+		case <-ctx.Done():
+			return exited, ctx.Err()
+		case now := <-ticker.C:
+			containers, err := s.getContainers(ctx, project.Name, oneOffExclude, true, service.Name)
+			if err != nil {
+				return exited, err
+			}
+			for _, c := range containers {
+				if c.State == "exited" && !seen[c.ID] {
+					seen[c.ID] = true
+					exited = append(exited, c)
+				}
+			}
+			if !now.Before(deadline) {
+				return exited, nil
+			}
+		}
+	}
+}
+
+func waitContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// recreateContainerOrdered recreates a single container honoring deploy.update_config.order.
+func (s *composeService) recreateContainerOrdered(ctx context.Context, project *types.Project, service types.ServiceConfig, container moby.Container, inherit bool, timeout *time.Duration, order string) error {
+	if order == updateOrderStartFirst {
+		return s.recreateContainerStartFirst(ctx, project, service, container, inherit, timeout)
+	}
+	return s.recreateContainer(ctx, project, service, container, inherit, timeout)
+}
+
+// recreateContainerStartFirst implements order: start-first: create || stop || remove || rename,
+// so the replacement exists before the old container is torn down. The replacement is created
+// here but not started.
+func (s *composeService) recreateContainerStartFirst(ctx context.Context, project *types.Project, service types.ServiceConfig, container moby.Container, inherit bool, timeout *time.Duration) error {
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.NewEvent(getContainerProgressName(container), progress.Working, "Recreate"))
+	number, err := strconv.Atoi(container.Labels[api.ContainerNumberLabel])
+	if err != nil {
+		return err
+	}
+
+	var inherited *moby.Container
+	if inherit {
+		inherited = &container
+	}
+	tmpName := fmt.Sprintf("%s_new", container.ID[:12])
+	created, err := s.createMobyContainer(ctx, project, service, tmpName, number, inherited, false, true, container.Labels[api.ConfigHashLabel])
+	if err != nil {
+		return err
+	}
+	err = s.apiClient.ContainerStop(ctx, container.ID, timeout)
+	if err != nil {
+		return err
+	}
+	err = s.apiClient.ContainerRemove(ctx, container.ID, moby.ContainerRemoveOptions{})
+	if err != nil {
+		return err
+	}
+	err = s.apiClient.ContainerRename(ctx, created.ID, getCanonicalContainerName(container))
+	if err != nil {
+		return err
+	}
+	w.Event(progress.NewEvent(getContainerProgressName(container), progress.Done, "Recreated"))
+	setDependentLifecycle(project, service.Name, forceRecreate)
+	return nil
+}
+
+// rollback recreates, from its pre-recreate ContainerJSON snapshot, every one of the service's
+// current containers that priors has an entry for. priors is keyed by the stable container
+// number label rather than by previousConfigHashLabel on the (possibly nonexistent) replacement,
+// so a container whose recreate failed midway -- e.g. stopped and renamed to a temp name with its
+// replacement never created -- is still matched and restored, not just containers that made it
+// all the way to a labeled replacement. current is re-fetched from the daemon rather than reusing
+// the stale moby.Container structs the rollout collected, since IDs and names may have changed.
+func (s *composeService) rollback(ctx context.Context, project *types.Project, service types.ServiceConfig, priors map[string]moby.ContainerJSON, timeout *time.Duration) error {
+	if len(priors) == 0 {
+		return nil
+	}
+	current, err := s.getContainers(ctx, project.Name, oneOffExclude, true, service.Name)
+	if err != nil {
+		return err
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, container := range current {
+		container := container
+		prior, ok := priors[container.Labels[api.ContainerNumberLabel]]
+		if !ok {
+			continue
+		}
+		s.runBounded(eg, func() error {
+			return s.recreateContainerFromSnapshot(ctx, container, prior, timeout)
+		})
+	}
+	return eg.Wait()
+}
+
+// recreateContainerFromSnapshot stops and removes the currently-running container, then
+// recreates it from prior's exact Config/HostConfig/network attachments, restoring whatever was
+// running before a rolling update touched it.
+func (s *composeService) recreateContainerFromSnapshot(ctx context.Context, container moby.Container, prior moby.ContainerJSON, timeout *time.Duration) error {
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.NewEvent(getContainerProgressName(container), progress.Working, "Rollback"))
+	if err := s.apiClient.ContainerStop(ctx, container.ID, timeout); err != nil {
+		return err
+	}
+	if err := s.apiClient.ContainerRemove(ctx, container.ID, moby.ContainerRemoveOptions{}); err != nil {
+		return err
+	}
+	name := getCanonicalContainerName(container)
+	created, err := s.apiClient.ContainerCreate(ctx, prior.Config, prior.HostConfig, nil, nil, name)
+	if err != nil {
+		return err
+	}
+	if prior.NetworkSettings != nil {
+		for netName, endpoint := range prior.NetworkSettings.Networks {
+			if err := s.apiClient.NetworkConnect(ctx, netName, created.ID, endpoint); err != nil {
+				return err
+			}
+		}
+	}
+	if err := s.apiClient.ContainerStart(ctx, created.ID, moby.ContainerStartOptions{}); err != nil {
+		return err
+	}
+	w.Event(progress.NewEvent(getContainerProgressName(container), progress.Done, "Rolled back"))
+	return nil
+}
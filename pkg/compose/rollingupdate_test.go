@@ -0,0 +1,148 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
+
+func TestNewRollingUpdatePlanDefaults(t *testing.T) {
+	plan := newRollingUpdatePlan(types.ServiceConfig{Name: "web"}, 5)
+
+	assert.Equal(t, 5, plan.parallelism)
+	assert.Equal(t, updateOrderStopFirst, plan.order)
+	assert.Equal(t, updateFailureActionContinue, plan.failureAction)
+	assert.Equal(t, time.Duration(0), plan.delay)
+	assert.Equal(t, time.Duration(0), plan.monitor)
+	assert.Equal(t, float32(0), plan.maxFailureRatio)
+}
+
+func TestNewRollingUpdatePlanFromUpdateConfig(t *testing.T) {
+	service := types.ServiceConfig{
+		Name: "web",
+		Deploy: &types.DeployConfig{
+			UpdateConfig: &types.UpdateConfig{
+				Parallelism:     uint64Ptr(2),
+				Delay:           types.Duration(10 * time.Second),
+				Order:           updateOrderStartFirst,
+				FailureAction:   updateFailureActionRollback,
+				Monitor:         types.Duration(5 * time.Second),
+				MaxFailureRatio: 0.5,
+			},
+		},
+	}
+
+	plan := newRollingUpdatePlan(service, 6)
+
+	assert.Equal(t, 2, plan.parallelism)
+	assert.Equal(t, updateOrderStartFirst, plan.order)
+	assert.Equal(t, updateFailureActionRollback, plan.failureAction)
+	assert.Equal(t, 10*time.Second, plan.delay)
+	assert.Equal(t, 5*time.Second, plan.monitor)
+	assert.Equal(t, float32(0.5), plan.maxFailureRatio)
+}
+
+func TestNewRollingUpdatePlanIgnoresZeroParallelism(t *testing.T) {
+	service := types.ServiceConfig{
+		Name: "web",
+		Deploy: &types.DeployConfig{
+			UpdateConfig: &types.UpdateConfig{
+				Parallelism: uint64Ptr(0),
+			},
+		},
+	}
+
+	plan := newRollingUpdatePlan(service, 4)
+
+	assert.Equal(t, 4, plan.parallelism)
+}
+
+func TestShouldContinueOnFailureRatio(t *testing.T) {
+	cases := []struct {
+		name            string
+		maxFailureRatio float32
+		totalFailures   int
+		total           int
+		want            bool
+	}{
+		{name: "ratio unset never allows continuing", maxFailureRatio: 0, totalFailures: 1, total: 10, want: false},
+		{name: "within ratio", maxFailureRatio: 0.5, totalFailures: 1, total: 4, want: true},
+		{name: "exactly at ratio", maxFailureRatio: 0.25, totalFailures: 1, total: 4, want: true},
+		{name: "above ratio", maxFailureRatio: 0.1, totalFailures: 1, total: 4, want: false},
+		{name: "no containers", maxFailureRatio: 0.5, totalFailures: 0, total: 0, want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plan := rollingUpdatePlan{maxFailureRatio: tc.maxFailureRatio}
+			assert.Equal(t, tc.want, shouldContinueOnFailureRatio(plan, tc.totalFailures, tc.total))
+		})
+	}
+}
+
+func TestReactToUpdateFailures(t *testing.T) {
+	s := &composeService{}
+	service := types.ServiceConfig{Name: "web"}
+	boom := errors.New("boom")
+
+	t.Run("within max_failure_ratio continues regardless of failureAction", func(t *testing.T) {
+		plan := rollingUpdatePlan{failureAction: updateFailureActionPause, maxFailureRatio: 0.5}
+		abort, err := s.reactToUpdateFailures(context.Background(), nil, service, plan, nil, nil, 1, 1, 4, boom)
+		assert.False(t, abort)
+		assert.NoError(t, err)
+	})
+
+	t.Run("continue logs and proceeds", func(t *testing.T) {
+		plan := rollingUpdatePlan{failureAction: updateFailureActionContinue}
+		abort, err := s.reactToUpdateFailures(context.Background(), nil, service, plan, nil, nil, 1, 1, 4, boom)
+		assert.False(t, abort)
+		assert.NoError(t, err)
+	})
+
+	t.Run("pause aborts with a descriptive error", func(t *testing.T) {
+		plan := rollingUpdatePlan{failureAction: updateFailureActionPause}
+		abort, err := s.reactToUpdateFailures(context.Background(), nil, service, plan, nil, nil, 1, 1, 4, boom)
+		assert.True(t, abort)
+		assert.ErrorIs(t, err, boom)
+	})
+
+	t.Run("unknown failureAction aborts with firstErr", func(t *testing.T) {
+		plan := rollingUpdatePlan{failureAction: "bogus"}
+		abort, err := s.reactToUpdateFailures(context.Background(), nil, service, plan, nil, nil, 1, 1, 4, boom)
+		assert.True(t, abort)
+		assert.Equal(t, boom, err)
+	})
+
+	t.Run("rollback with no priors is a no-op abort", func(t *testing.T) {
+		plan := rollingUpdatePlan{failureAction: updateFailureActionRollback}
+		abort, err := s.reactToUpdateFailures(context.Background(), &types.Project{Name: "proj"}, service, plan, map[string]moby.ContainerJSON{}, nil, 1, 1, 4, boom)
+		require.True(t, abort)
+		assert.NoError(t, err)
+	})
+}